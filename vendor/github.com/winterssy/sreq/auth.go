@@ -0,0 +1,66 @@
+package sreq
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/winterssy/sreq/auth"
+)
+
+// SetAuth registers an auth.Authenticator that applies credentials to
+// every outgoing request. When a request comes back 401, the
+// Authenticator is given a chance to refresh its credentials and trigger
+// one automatic retry, regardless of the request's own Retry.MaxAttempts.
+func (c *Client) SetAuth(a auth.Authenticator) *Client {
+	c.authenticator = a
+	return c
+}
+
+func (c *Client) doWithAuth(req *Request, resp *Response) {
+	if c.authenticator != nil {
+		if err := c.authenticator.Apply(req.RawRequest); err != nil {
+			resp.err = err
+			return
+		}
+	}
+
+	c.doWithRetry(req, resp)
+
+	if c.authenticator == nil || resp.err != nil || resp.RawResponse == nil ||
+		resp.RawResponse.StatusCode != http.StatusUnauthorized {
+		return
+	}
+
+	retry, err := c.authenticator.Refresh(resp.RawResponse)
+	if err != nil {
+		resp.err = err
+		return
+	}
+	if !retry {
+		return
+	}
+
+	// Drain and close the 401 response's body before retrying, so its
+	// connection returns to the pool. NTLMTransport pins a host to a
+	// single connection (MaxConnsPerHost: 1); leaving this body open
+	// keeps that connection checked out and blocks the Type-3 retry
+	// until the Client's overall Timeout fires instead of reusing it.
+	io.Copy(ioutil.Discard, resp.RawResponse.Body)
+	resp.RawResponse.Body.Close()
+
+	if err := c.authenticator.Apply(req.RawRequest); err != nil {
+		resp.err = err
+		return
+	}
+
+	// The first doWithRetry call drained req.RawRequest.Body to EOF and
+	// the transport closed it; restore it from GetBody the same way
+	// doWithRetry does between attempts of a single call, or the
+	// refreshed retry goes out with an empty body.
+	if req.RawRequest.GetBody != nil {
+		req.RawRequest.Body, _ = req.RawRequest.GetBody()
+	}
+
+	c.doWithRetry(req, resp)
+}
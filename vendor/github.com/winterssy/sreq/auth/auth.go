@@ -0,0 +1,33 @@
+// Package auth provides pluggable request authentication for sreq.Client.
+package auth
+
+import "net/http"
+
+type (
+	// Authenticator applies credentials to outgoing requests and knows how
+	// to refresh them when a server rejects a request as unauthenticated.
+	// Register one with Client.SetAuth.
+	Authenticator interface {
+		// Apply adds credentials to req before it's sent.
+		Apply(req *http.Request) error
+
+		// Refresh inspects a response that failed authentication and
+		// reports whether the request should be retried with refreshed
+		// credentials.
+		Refresh(resp *http.Response) (retry bool, err error)
+	}
+)
+
+// errAuthenticator is an Authenticator that always fails with err, used to
+// stub out build-tag-gated implementations for builds that omit them.
+type errAuthenticator struct {
+	err error
+}
+
+func (e errAuthenticator) Apply(*http.Request) error {
+	return e.err
+}
+
+func (e errAuthenticator) Refresh(*http.Response) (bool, error) {
+	return false, e.err
+}
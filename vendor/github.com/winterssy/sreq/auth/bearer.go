@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// expiryLeeway is how far ahead of a token's expiry Bearer proactively
+// refreshes it, to avoid racing a request against expiry.
+const expiryLeeway = 30 * time.Second
+
+type (
+	// Token is an OAuth2-style bearer credential.
+	Token struct {
+		AccessToken string
+		ExpiresAt   time.Time
+	}
+
+	// TokenSource supplies and refreshes bearer tokens. Implementations
+	// are responsible for their own caching/persistence; Bearer calls
+	// Token once for the first token, and RefreshToken when the cached
+	// token is near expiry or a request comes back 401.
+	TokenSource interface {
+		Token() (*Token, error)
+		RefreshToken() (*Token, error)
+	}
+
+	bearerAuth struct {
+		source TokenSource
+
+		mu    sync.Mutex
+		token *Token
+	}
+)
+
+// Bearer returns an Authenticator that attaches an
+// "Authorization: Bearer <token>" header, proactively refreshing the token
+// shortly before it expires and reactively refreshing it on a 401
+// response.
+func Bearer(source TokenSource) Authenticator {
+	return &bearerAuth{source: source}
+}
+
+func (b *bearerAuth) Apply(req *http.Request) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.token == nil:
+		token, err := b.source.Token()
+		if err != nil {
+			return err
+		}
+		b.token = token
+	case !b.token.ExpiresAt.IsZero() && time.Now().Add(expiryLeeway).After(b.token.ExpiresAt):
+		token, err := b.source.RefreshToken()
+		if err != nil {
+			return err
+		}
+		b.token = token
+	}
+
+	req.Header.Set("Authorization", "Bearer "+b.token.AccessToken)
+	return nil
+}
+
+func (b *bearerAuth) Refresh(resp *http.Response) (bool, error) {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	token, err := b.source.RefreshToken()
+	if err != nil {
+		return false, err
+	}
+
+	b.token = token
+	return true, nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type ntlmAuth struct {
+	user, pass, domain string
+
+	mu    sync.Mutex
+	type3 string // cached base64 Type-3 token for the current connection
+}
+
+// NTLM returns an Authenticator that performs the NTLM three-message
+// handshake (Type-1 negotiate, Type-2 challenge, Type-3 authenticate)
+// against servers that require Windows-integrated authentication.
+//
+// NTLM is connection-oriented: the handshake must complete on the same TCP
+// connection the authenticated request is sent over. An Authenticator
+// never sees the transport a Client sends requests through, so it cannot
+// pin that connection by itself — pair NTLM with an NTLMTransport (set via
+// Client.SetTransport, or on a dedicated Client.Endpoint for the NTLM
+// host) to guarantee the handshake converges.
+func NTLM(user, pass, domain string) Authenticator {
+	return &ntlmAuth{user: user, pass: pass, domain: domain}
+}
+
+func (n *ntlmAuth) Apply(req *http.Request) error {
+	n.mu.Lock()
+	type3 := n.type3
+	n.mu.Unlock()
+
+	if type3 == "" {
+		req.Header.Set("Authorization", "NTLM "+negotiateMessage())
+		return nil
+	}
+
+	req.Header.Set("Authorization", "NTLM "+type3)
+	return nil
+}
+
+func (n *ntlmAuth) Refresh(resp *http.Response) (bool, error) {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false, nil
+	}
+
+	challenge := resp.Header.Get("Www-Authenticate")
+	if !strings.HasPrefix(challenge, "NTLM ") {
+		n.mu.Lock()
+		n.type3 = ""
+		n.mu.Unlock()
+		return false, errNoNTLMChallenge
+	}
+
+	type2, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(challenge, "NTLM "))
+	if err != nil {
+		return false, err
+	}
+
+	serverChallenge, targetInfo, err := parseChallengeMessage(type2)
+	if err != nil {
+		return false, err
+	}
+
+	type3, err := authenticateMessage(n.user, n.pass, n.domain, serverChallenge, targetInfo)
+	if err != nil {
+		return false, err
+	}
+
+	n.mu.Lock()
+	n.type3 = base64.StdEncoding.EncodeToString(type3)
+	n.mu.Unlock()
+	return true, nil
+}
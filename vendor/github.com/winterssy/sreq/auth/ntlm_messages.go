@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+var errNoNTLMChallenge = errors.New("auth: server did not return an NTLM Type-2 challenge")
+var errMalformedChallenge = errors.New("auth: malformed NTLM Type-2 challenge message")
+
+// negotiateFlags are the flags sreq advertises in the Type-1 message:
+// Unicode, OEM, Request Target, NTLM, Always Sign, NTLM2 Key.
+const negotiateFlags = 0x00088207
+
+func negotiateMessage() string {
+	msg := make([]byte, 32)
+	copy(msg[0:8], ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:12], 1)
+	binary.LittleEndian.PutUint32(msg[12:16], negotiateFlags)
+	return base64.StdEncoding.EncodeToString(msg)
+}
+
+func parseChallengeMessage(msg []byte) (serverChallenge, targetInfo []byte, err error) {
+	if len(msg) < 32 || !bytes.Equal(msg[0:8], ntlmSignature) {
+		return nil, nil, errMalformedChallenge
+	}
+
+	serverChallenge = append([]byte(nil), msg[24:32]...)
+	if len(msg) < 48 {
+		return serverChallenge, nil, nil
+	}
+
+	tiLen := binary.LittleEndian.Uint16(msg[40:42])
+	tiOffset := binary.LittleEndian.Uint32(msg[44:48])
+	if tiLen == 0 || int(tiOffset)+int(tiLen) > len(msg) {
+		return serverChallenge, nil, nil
+	}
+
+	targetInfo = append([]byte(nil), msg[tiOffset:tiOffset+uint32(tiLen)]...)
+	return serverChallenge, targetInfo, nil
+}
+
+// ntowfv2 derives the NTLMv2 response key from the password, username and
+// domain, per MS-NLMP 3.3.2.
+func ntowfv2(user, pass, domain string) []byte {
+	h := md4.New()
+	h.Write(utf16le(pass))
+	ntlmHash := h.Sum(nil)
+
+	mac := hmac.New(md5.New, ntlmHash)
+	mac.Write(utf16le(strings.ToUpper(user) + domain))
+	return mac.Sum(nil)
+}
+
+// authenticateMessage builds the Type-3 message containing the NTLMv2
+// response to the server's challenge.
+func authenticateMessage(user, pass, domain string, serverChallenge, targetInfo []byte) ([]byte, error) {
+	responseKey := ntowfv2(user, pass, domain)
+
+	clientChallenge := make([]byte, 8)
+	if _, err := rand.Read(clientChallenge); err != nil {
+		return nil, err
+	}
+
+	clientData := new(bytes.Buffer)
+	clientData.Write([]byte{0x01, 0x01, 0x00, 0x00}) // resp type, hi-resp type
+	clientData.Write([]byte{0x00, 0x00, 0x00, 0x00}) // reserved
+	binary.Write(clientData, binary.LittleEndian, ntTimestamp(time.Now()))
+	clientData.Write(clientChallenge)
+	clientData.Write([]byte{0x00, 0x00, 0x00, 0x00}) // reserved
+	clientData.Write(targetInfo)
+	clientData.Write([]byte{0x00, 0x00, 0x00, 0x00}) // reserved
+
+	mac := hmac.New(md5.New, responseKey)
+	mac.Write(serverChallenge)
+	mac.Write(clientData.Bytes())
+	ntProofStr := mac.Sum(nil)
+
+	ntChallengeResponse := append(append([]byte(nil), ntProofStr...), clientData.Bytes()...)
+	return buildType3Message(user, domain, ntChallengeResponse), nil
+}
+
+// buildType3Message lays out the NTLM authenticate message's fixed header
+// and security buffers, per MS-NLMP 2.2.1.3. LM response is left empty
+// since the NTLMv2 response alone is sufficient for servers that support
+// it.
+func buildType3Message(user, domain string, ntResponse []byte) []byte {
+	domainBytes := utf16le(domain)
+	userBytes := utf16le(user)
+	workstationBytes := utf16le("")
+	lmResponse := []byte{}
+
+	const headerLen = 64
+	offset := uint32(headerLen)
+
+	lmOffset := offset
+	offset += uint32(len(lmResponse))
+	ntOffset := offset
+	offset += uint32(len(ntResponse))
+	domainOffset := offset
+	offset += uint32(len(domainBytes))
+	userOffset := offset
+	offset += uint32(len(userBytes))
+	workstationOffset := offset
+	offset += uint32(len(workstationBytes))
+
+	msg := make([]byte, offset)
+	copy(msg[0:8], ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:12], 3)
+
+	putSecurityBuffer(msg, 12, lmResponse, lmOffset)
+	putSecurityBuffer(msg, 20, ntResponse, ntOffset)
+	putSecurityBuffer(msg, 28, domainBytes, domainOffset)
+	putSecurityBuffer(msg, 36, userBytes, userOffset)
+	putSecurityBuffer(msg, 44, workstationBytes, workstationOffset)
+	binary.LittleEndian.PutUint32(msg[60:64], negotiateFlags)
+
+	copy(msg[lmOffset:], lmResponse)
+	copy(msg[ntOffset:], ntResponse)
+	copy(msg[domainOffset:], domainBytes)
+	copy(msg[userOffset:], userBytes)
+	copy(msg[workstationOffset:], workstationBytes)
+
+	return msg
+}
+
+func putSecurityBuffer(msg []byte, at int, data []byte, offset uint32) {
+	binary.LittleEndian.PutUint16(msg[at:at+2], uint16(len(data)))
+	binary.LittleEndian.PutUint16(msg[at+2:at+4], uint16(len(data)))
+	binary.LittleEndian.PutUint32(msg[at+4:at+8], offset)
+}
+
+func utf16le(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	buf := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[i*2:], u)
+	}
+	return buf
+}
+
+// ntTimestamp converts t to the number of 100-nanosecond intervals since
+// 1601-01-01, the epoch NTLMv2 timestamps use.
+func ntTimestamp(t time.Time) uint64 {
+	const epochDelta = 11644473600 // seconds between 1601 and 1970
+	return uint64(t.Unix()+epochDelta)*10000000 + uint64(t.Nanosecond()/100)
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+func TestNTTimestampEpoch(t *testing.T) {
+	got := ntTimestamp(time.Unix(0, 0).UTC())
+	want := uint64(11644473600) * 10000000
+	if got != want {
+		t.Fatalf("ntTimestamp(epoch) = %d, want %d", got, want)
+	}
+}
+
+func TestNtowfv2IsDeterministicAndKeyed(t *testing.T) {
+	key1 := ntowfv2("user", "pass", "DOMAIN")
+	key2 := ntowfv2("user", "pass", "DOMAIN")
+	if !bytes.Equal(key1, key2) {
+		t.Fatal("ntowfv2 is not deterministic for identical inputs")
+	}
+	if len(key1) != 16 {
+		t.Fatalf("ntowfv2 returned %d bytes, want 16 (an HMAC-MD5 digest)", len(key1))
+	}
+
+	if key3 := ntowfv2("user", "different", "DOMAIN"); bytes.Equal(key1, key3) {
+		t.Fatal("ntowfv2 produced the same key for two different passwords")
+	}
+	if key4 := ntowfv2("other", "pass", "DOMAIN"); bytes.Equal(key1, key4) {
+		t.Fatal("ntowfv2 produced the same key for two different usernames")
+	}
+}
+
+func TestParseChallengeMessageRoundTrip(t *testing.T) {
+	serverChallenge := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	targetInfo := []byte{0xAA, 0xBB, 0xCC, 0xDD}
+
+	msg := make([]byte, 48+len(targetInfo))
+	copy(msg[0:8], ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:12], 2)
+	copy(msg[24:32], serverChallenge)
+	binary.LittleEndian.PutUint16(msg[40:42], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint16(msg[42:44], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint32(msg[44:48], 48)
+	copy(msg[48:], targetInfo)
+
+	gotChallenge, gotTargetInfo, err := parseChallengeMessage(msg)
+	if err != nil {
+		t.Fatalf("parseChallengeMessage: %v", err)
+	}
+	if !bytes.Equal(gotChallenge, serverChallenge) {
+		t.Fatalf("serverChallenge = %x, want %x", gotChallenge, serverChallenge)
+	}
+	if !bytes.Equal(gotTargetInfo, targetInfo) {
+		t.Fatalf("targetInfo = %x, want %x", gotTargetInfo, targetInfo)
+	}
+}
+
+func TestParseChallengeMessageMalformed(t *testing.T) {
+	if _, _, err := parseChallengeMessage([]byte("too short")); err != errMalformedChallenge {
+		t.Fatalf("parseChallengeMessage error = %v, want errMalformedChallenge", err)
+	}
+}
+
+func TestBuildType3MessageRoundTrip(t *testing.T) {
+	ntResponse := bytes.Repeat([]byte{0x42}, 16+28)
+	msg := buildType3Message("alice", "EXAMPLE", ntResponse)
+
+	if !bytes.Equal(msg[0:8], ntlmSignature) {
+		t.Fatal("Type-3 message is missing the NTLMSSP signature")
+	}
+	if got := binary.LittleEndian.Uint32(msg[8:12]); got != 3 {
+		t.Fatalf("message type = %d, want 3", got)
+	}
+
+	ntLen := binary.LittleEndian.Uint16(msg[20:22])
+	ntOffset := binary.LittleEndian.Uint32(msg[24:28])
+	if got := msg[ntOffset : ntOffset+uint32(ntLen)]; !bytes.Equal(got, ntResponse) {
+		t.Fatalf("NT response round-trip mismatch: got %x, want %x", got, ntResponse)
+	}
+
+	domainLen := binary.LittleEndian.Uint16(msg[28:30])
+	domainOffset := binary.LittleEndian.Uint32(msg[32:36])
+	if got := decodeUTF16LE(msg[domainOffset : domainOffset+uint32(domainLen)]); got != "EXAMPLE" {
+		t.Fatalf("domain round-trip = %q, want %q", got, "EXAMPLE")
+	}
+
+	userLen := binary.LittleEndian.Uint16(msg[36:38])
+	userOffset := binary.LittleEndian.Uint32(msg[40:44])
+	if got := decodeUTF16LE(msg[userOffset : userOffset+uint32(userLen)]); got != "alice" {
+		t.Fatalf("user round-trip = %q, want %q", got, "alice")
+	}
+}
+
+func decodeUTF16LE(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(units))
+}
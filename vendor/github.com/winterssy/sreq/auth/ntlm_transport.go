@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// NTLMTransport wraps an http.RoundTripper to guarantee that an NTLM
+// handshake (paired with the Authenticator returned by NTLM) completes on
+// a single TCP connection per host, as NTLM requires: it serializes NTLM
+// round trips per host and disables connection close between them, so a
+// Type-3 authenticate message is never dispatched on a different
+// connection than the one that received its Type-2 challenge.
+//
+// Base should have at most one connection per NTLM host available (e.g. an
+// *http.Transport with MaxConnsPerHost set to 1 for that host, which
+// NewNTLMTransport arranges automatically); otherwise the pool may still
+// hand the Type-3 request a second, unauthenticated connection even with
+// NTLM round trips serialized.
+type NTLMTransport struct {
+	Base http.RoundTripper
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// NewNTLMTransport returns an NTLMTransport backed by base, or by a clone
+// of http.DefaultTransport if base is nil. In both cases it sets that
+// transport's MaxConnsPerHost to 1 and enables keep-alives on it.
+func NewNTLMTransport(base *http.Transport) *NTLMTransport {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	base.MaxConnsPerHost = 1
+	base.DisableKeepAlives = false
+
+	return &NTLMTransport{Base: base}
+}
+
+func (t *NTLMTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "NTLM ") {
+		return base.RoundTrip(req)
+	}
+
+	lock := t.hostLock(req.URL.Host)
+	lock.Lock()
+	defer lock.Unlock()
+
+	req.Close = false
+	return base.RoundTrip(req)
+}
+
+func (t *NTLMTransport) hostLock(host string) *sync.Mutex {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.locks == nil {
+		t.locks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := t.locks[host]
+	if !ok {
+		lock = new(sync.Mutex)
+		t.locks[host] = lock
+	}
+	return lock
+}
@@ -0,0 +1,9 @@
+package auth
+
+// SPNEGOConfig configures SPNEGO/Kerberos authentication.
+type SPNEGOConfig struct {
+	// SPN is the service principal name to negotiate against, e.g.
+	// "HTTP/host.example.com". Leave empty to derive it from each
+	// request's host.
+	SPN string
+}
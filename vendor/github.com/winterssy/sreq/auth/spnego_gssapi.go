@@ -0,0 +1,41 @@
+//go:build spnego
+// +build spnego
+
+package auth
+
+import (
+	"net/http"
+
+	"github.com/dpotapov/go-spnego"
+)
+
+type spnegoAuth struct {
+	cfg       SPNEGOConfig
+	transport spnego.Transport
+}
+
+// SPNEGO returns an Authenticator that negotiates Kerberos credentials via
+// SPNEGO/GSSAPI. It requires building with the "spnego" tag, which pulls in
+// a GSSAPI dependency most non-server builds don't need.
+func SPNEGO(cfg SPNEGOConfig) Authenticator {
+	return &spnegoAuth{cfg: cfg}
+}
+
+func (s *spnegoAuth) Apply(req *http.Request) error {
+	spn := s.cfg.SPN
+	if spn == "" {
+		spn = "HTTP/" + req.URL.Hostname()
+	}
+
+	token, err := s.transport.GetToken(spn)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Negotiate "+token)
+	return nil
+}
+
+func (s *spnegoAuth) Refresh(resp *http.Response) (bool, error) {
+	return resp.StatusCode == http.StatusUnauthorized, nil
+}
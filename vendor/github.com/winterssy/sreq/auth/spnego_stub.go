@@ -0,0 +1,15 @@
+//go:build !spnego
+// +build !spnego
+
+package auth
+
+import "errors"
+
+// SPNEGO requires building with the "spnego" tag; see spnego_gssapi.go.
+// Without it, this returns an Authenticator that always fails, so callers
+// get a clear error instead of a missing symbol at compile time.
+func SPNEGO(cfg SPNEGOConfig) Authenticator {
+	return errAuthenticator{
+		err: errors.New("auth: SPNEGO support requires building with the \"spnego\" tag"),
+	}
+}
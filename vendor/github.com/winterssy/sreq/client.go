@@ -12,9 +12,13 @@ import (
 	"net/http/cookiejar"
 	neturl "net/url"
 	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/net/http2"
 	"golang.org/x/net/publicsuffix"
+
+	"github.com/winterssy/sreq/auth"
 )
 
 const (
@@ -34,8 +38,27 @@ type (
 	Client struct {
 		RawClient *http.Client
 
+		// baseTransport and h2Transport remember the plain HTTP/1.1
+		// transport and its HTTP/2 overlay (if any) installed by
+		// EnableHTTP2 or EnableH2C, so httpTransport keeps returning a
+		// usable *http.Transport even after RawClient.Transport has
+		// been swapped for an h2c transport.
+		baseTransport *http.Transport
+		h2Transport   *http2.Transport
+
 		beforeRequestHooks []BeforeRequestHook
 		afterResponseHooks []AfterResponseHook
+
+		endpointsMu sync.Mutex
+		endpoints   map[string]*Endpoint
+
+		authenticator auth.Authenticator
+
+		tracer       Tracer
+		traceSampler Sampler
+		redactor     Redactor
+
+		rateLimiter RateLimiter
 	}
 )
 
@@ -57,6 +80,10 @@ func New() *Client {
 }
 
 func (c *Client) httpTransport() (*http.Transport, error) {
+	if c.baseTransport != nil {
+		return c.baseTransport, nil
+	}
+
 	t, ok := c.RawClient.Transport.(*http.Transport)
 	if !ok {
 		return nil, ErrUnexpectedTransport
@@ -355,8 +382,15 @@ func (c *Client) FilterCookie(url string, name string) (*http.Cookie, error) {
 }
 
 // Do sends a request and returns its  response.
+// If an Endpoint was registered for the request's scheme and host via
+// Client.Endpoint,
+// it's used to perform the request instead of the parent client, and its
+// hooks run in addition to the parent's.
 func (c *Client) Do(req *Request) *Response {
-	resp := new(Response)
+	resp := &Response{
+		client:  c,
+		request: req,
+	}
 
 	if err := c.onBeforeRequest(req); err != nil {
 		resp.err = err
@@ -364,7 +398,21 @@ func (c *Client) Do(req *Request) *Response {
 	}
 
 	req.Sync()
-	c.doWithRetry(req, resp)
+
+	sender := c
+	ep := c.matchEndpoint(req)
+	if ep != nil {
+		if err := ep.onBeforeRequest(req); err != nil {
+			resp.err = err
+			return resp
+		}
+		sender = ep.Client
+	}
+
+	sender.doWithAuth(req, resp)
+	if ep != nil {
+		ep.onAfterResponse(resp)
+	}
 	c.onAfterResponse(resp)
 	return resp
 }
@@ -404,14 +452,33 @@ func (c *Client) doWithRetry(req *Request, resp *Response) {
 	}
 	req.RawRequest = req.RawRequest.WithContext(ctx)
 
+	// Attach the ClientTrace once for the whole call, not per attempt:
+	// httptrace.WithClientTrace composes with whatever trace is already
+	// on the context rather than replacing it, so re-attaching inside
+	// the retry loop below would make attempt N re-fire attempts
+	// 1..N-1's hooks alongside its own.
+	traced := c.shouldTrace(req)
+	if traced {
+		c.attachClientTrace(req)
+	}
+
 	var err error
 	for i := 0; i < retry.MaxAttempts; i++ {
-		resp.RawResponse, resp.err = c.do(req)
+		if c.rateLimiter != nil {
+			if err = c.rateLimiter.Wait(ctx, req.RawRequest.URL.Host); err != nil {
+				resp.err = err
+				return
+			}
+		}
+
+		resp.RawResponse, resp.err = c.do(req, traced)
 		if err = ctx.Err(); err != nil {
 			resp.err = err
 			return
 		}
 
+		c.penalizeIfThrottled(req, resp)
+
 		if i == retry.MaxAttempts-1 {
 			return
 		}
@@ -434,8 +501,15 @@ func (c *Client) doWithRetry(req *Request, resp *Response) {
 	}
 }
 
-func (c *Client) do(req *Request) (*http.Response, error) {
+func (c *Client) do(req *Request, traced bool) (*http.Response, error) {
+	if traced {
+		c.traceEvent(TraceRequestStart, req, nil)
+	}
+
 	rawResponse, err := c.RawClient.Do(req.RawRequest)
+	if traced {
+		defer c.traceEvent(TraceRequestDone, req, err)
+	}
 	if err != nil {
 		return rawResponse, err
 	}
@@ -0,0 +1,65 @@
+package sreq
+
+import "testing"
+
+func TestSplitRangesCoversEveryByteExactlyOnce(t *testing.T) {
+	cases := []struct {
+		total int64
+		n     int
+	}{
+		{total: 100, n: 4},
+		{total: 100, n: 3},
+		{total: 1, n: 4},
+		{total: 0, n: 4},
+		{total: 7, n: 8},
+		{total: 1000, n: 1},
+	}
+
+	for _, c := range cases {
+		ranges := splitRanges(c.total, c.n)
+		covered := make([]bool, c.total)
+
+		var prevEnd int64 = -1
+		for _, r := range ranges {
+			if r.start != prevEnd+1 {
+				t.Fatalf("total=%d n=%d: range %+v does not start right after previous end %d", c.total, c.n, r, prevEnd)
+			}
+			if r.end < r.start {
+				t.Fatalf("total=%d n=%d: range %+v has end < start", c.total, c.n, r)
+			}
+			for i := r.start; i <= r.end; i++ {
+				if covered[i] {
+					t.Fatalf("total=%d n=%d: byte %d covered by more than one range", c.total, c.n, i)
+				}
+				covered[i] = true
+			}
+			prevEnd = r.end
+		}
+
+		if len(ranges) > 0 && prevEnd != c.total-1 {
+			t.Fatalf("total=%d n=%d: last range ends at %d, want %d", c.total, c.n, prevEnd, c.total-1)
+		}
+		for i, ok := range covered {
+			if !ok {
+				t.Fatalf("total=%d n=%d: byte %d not covered by any range", c.total, c.n, i)
+			}
+		}
+	}
+}
+
+func TestSplitRangesNeverExceedsRequestedConcurrency(t *testing.T) {
+	ranges := splitRanges(100, 4)
+	if len(ranges) != 4 {
+		t.Fatalf("len(ranges) = %d, want 4", len(ranges))
+	}
+}
+
+func TestSplitRangesFallsBackToOneRangeWhenSmallerThanConcurrency(t *testing.T) {
+	ranges := splitRanges(3, 8)
+	if len(ranges) != 1 {
+		t.Fatalf("len(ranges) = %d, want 1 for total smaller than requested concurrency", len(ranges))
+	}
+	if ranges[0] != (byteRange{start: 0, end: 2}) {
+		t.Fatalf("ranges[0] = %+v, want {0 2}", ranges[0])
+	}
+}
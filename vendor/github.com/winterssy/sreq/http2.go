@@ -0,0 +1,85 @@
+package sreq
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+type (
+	// HTTP2Config specifies tuning parameters for an HTTP/2 transport.
+	HTTP2Config struct {
+		ReadIdleTimeout            time.Duration
+		PingTimeout                time.Duration
+		MaxHeaderListSize          uint32
+		StrictMaxConcurrentStreams bool
+	}
+)
+
+// ErrHTTP2NotEnabled is returned by ConfigureHTTP2 when neither EnableHTTP2
+// nor EnableH2C has been called yet.
+var ErrHTTP2NotEnabled = errors.New("sreq: http2 not enabled")
+
+// EnableHTTP2 upgrades the HTTP client's transport to speak HTTP/2 over TLS
+// with prior knowledge, keeping the existing *http.Transport as its base.
+func (c *Client) EnableHTTP2() (*Client, error) {
+	t, err := c.httpTransport()
+	if err != nil {
+		return c, &Error{
+			Op:  "Client.EnableHTTP2",
+			Err: err,
+		}
+	}
+
+	h2Transport, err := http2.ConfigureTransport(t)
+	if err != nil {
+		return c, &Error{
+			Op:  "Client.EnableHTTP2",
+			Err: err,
+		}
+	}
+
+	c.baseTransport = t
+	c.h2Transport = h2Transport
+	return c, nil
+}
+
+// EnableH2C makes the HTTP client speak cleartext HTTP/2 (h2c), bypassing
+// TLS negotiation entirely. Use it against servers that support prior
+// knowledge h2c, such as internal gRPC-style endpoints.
+func (c *Client) EnableH2C() *Client {
+	if t, err := c.httpTransport(); err == nil {
+		c.baseTransport = t
+	}
+
+	h2Transport := &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}
+
+	c.h2Transport = h2Transport
+	c.RawClient.Transport = h2Transport
+	return c
+}
+
+// ConfigureHTTP2 tunes the HTTP/2 transport previously installed by
+// EnableHTTP2 or EnableH2C.
+func (c *Client) ConfigureHTTP2(cfg HTTP2Config) (*Client, error) {
+	if c.h2Transport == nil {
+		return c, &Error{
+			Op:  "Client.ConfigureHTTP2",
+			Err: ErrHTTP2NotEnabled,
+		}
+	}
+
+	c.h2Transport.ReadIdleTimeout = cfg.ReadIdleTimeout
+	c.h2Transport.PingTimeout = cfg.PingTimeout
+	c.h2Transport.MaxHeaderListSize = cfg.MaxHeaderListSize
+	c.h2Transport.StrictMaxConcurrentStreams = cfg.StrictMaxConcurrentStreams
+	return c, nil
+}
@@ -0,0 +1,203 @@
+package sreq
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// RateLimiter throttles outgoing requests, optionally per host.
+	RateLimiter interface {
+		// Wait blocks until a request to host is allowed to proceed, or
+		// ctx is done.
+		Wait(ctx context.Context, host string) error
+	}
+
+	// AdaptiveRateLimiter additionally reacts to server-signaled
+	// backpressure (429/503 plus Retry-After) by throttling the
+	// offending host harder until a cooldown window elapses.
+	AdaptiveRateLimiter interface {
+		RateLimiter
+
+		// Penalize shrinks the allowance for host until cooldown
+		// elapses.
+		Penalize(host string, cooldown time.Duration)
+	}
+)
+
+// SetRateLimiter registers a RateLimiter consulted before every attempt in
+// doWithRetry, composing with the existing backoff loop. If rl also
+// implements AdaptiveRateLimiter, a 429/503 response's Retry-After header
+// throttles that host until the cooldown elapses.
+func (c *Client) SetRateLimiter(rl RateLimiter) *Client {
+	c.rateLimiter = rl
+	return c
+}
+
+func (c *Client) penalizeIfThrottled(req *Request, resp *Response) {
+	if c.rateLimiter == nil || resp.RawResponse == nil {
+		return
+	}
+	if resp.RawResponse.StatusCode != http.StatusTooManyRequests &&
+		resp.RawResponse.StatusCode != http.StatusServiceUnavailable {
+		return
+	}
+
+	adaptive, ok := c.rateLimiter.(AdaptiveRateLimiter)
+	if !ok {
+		return
+	}
+
+	if cooldown, ok := parseRetryAfter(resp.RawResponse); ok {
+		adaptive.Penalize(req.RawRequest.URL.Host, cooldown)
+	}
+}
+
+// parseRetryAfter reads a Retry-After header in either of its two allowed
+// forms: a number of seconds, or an HTTP-date.
+func parseRetryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			secs = 0
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
+
+// tokenBucket is a standard token-bucket limiter: tokens accrue at rps per
+// second up to burst, and each Wait call consumes one.
+type tokenBucket struct {
+	rps   float64
+	burst float64
+
+	mu            sync.Mutex
+	tokens        float64
+	last          time.Time
+	cooldownUntil time.Time
+}
+
+func newTokenBucket(rps float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rps:    rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// NewTokenBucket returns a RateLimiter that allows rps requests per second
+// on average, with bursts up to burst. The same bucket is shared by every
+// host.
+func NewTokenBucket(rps float64, burst int) RateLimiter {
+	return newTokenBucket(rps, burst)
+}
+
+func (b *tokenBucket) Wait(ctx context.Context, _ string) error {
+	for {
+		b.mu.Lock()
+		b.refillLocked(time.Now())
+
+		if cooldown := b.cooldownUntil; cooldown.After(time.Now()) {
+			b.mu.Unlock()
+			select {
+			case <-time.After(time.Until(cooldown)):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		wait := time.Duration((1 - b.tokens) / b.rps * float64(time.Second))
+		b.mu.Unlock()
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *tokenBucket) refillLocked(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+
+	b.tokens += elapsed * b.rps
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *tokenBucket) penalize(cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = 0
+	if until := time.Now().Add(cooldown); until.After(b.cooldownUntil) {
+		b.cooldownUntil = until
+	}
+}
+
+// perHostTokenBucket gives every host its own tokenBucket, seeded with the
+// same rps/burst, and lets 429/503 responses shrink a single host's
+// bucket without throttling the others.
+type perHostTokenBucket struct {
+	rps   float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// NewPerHostTokenBucket returns an AdaptiveRateLimiter with an independent
+// token bucket per host, each seeded with rps/burst.
+func NewPerHostTokenBucket(rps float64, burst int) AdaptiveRateLimiter {
+	return &perHostTokenBucket{
+		rps:     rps,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (p *perHostTokenBucket) bucket(host string) *tokenBucket {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	b, ok := p.buckets[host]
+	if !ok {
+		b = newTokenBucket(p.rps, p.burst)
+		p.buckets[host] = b
+	}
+	return b
+}
+
+func (p *perHostTokenBucket) Wait(ctx context.Context, host string) error {
+	return p.bucket(host).Wait(ctx, host)
+}
+
+func (p *perHostTokenBucket) Penalize(host string, cooldown time.Duration) {
+	p.bucket(host).penalize(cooldown)
+}
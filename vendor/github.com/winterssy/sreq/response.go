@@ -15,6 +15,12 @@ type (
 	Response struct {
 		RawResponse *http.Response
 		Err         error
+
+		// client and request back the originating Client and Request,
+		// used by SaveWithOptions to issue follow-up ranged requests
+		// for resume and concurrent download support.
+		client  *Client
+		request *Request
 	}
 )
 
@@ -0,0 +1,246 @@
+package sreq
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	neturl "net/url"
+	"sync"
+	"time"
+)
+
+// Trace event names, mirroring the httptrace.ClientTrace callbacks they're
+// derived from.
+const (
+	TraceRequestStart         = "RequestStart"
+	TraceDNSDone              = "DNSDone"
+	TraceConnectDone          = "ConnectDone"
+	TraceTLSHandshakeDone     = "TLSHandshakeDone"
+	TraceWroteRequest         = "WroteRequest"
+	TraceGotFirstResponseByte = "GotFirstResponseByte"
+	TraceRequestDone          = "RequestDone"
+)
+
+type (
+	// TraceEvent is a single structured point captured during a traced
+	// request's lifecycle. Headers and Query have already been passed
+	// through the client's Redactor.
+	TraceEvent struct {
+		Name      string        `json:"name"`
+		Timestamp time.Time     `json:"timestamp"`
+		Method    string        `json:"method"`
+		URL       string        `json:"url"`
+		Host      string        `json:"host"`
+		Headers   http.Header   `json:"headers,omitempty"`
+		Query     neturl.Values `json:"query,omitempty"`
+		Err       error         `json:"err,omitempty"`
+	}
+
+	// Tracer receives structured trace events for sampled requests.
+	Tracer interface {
+		Trace(event TraceEvent)
+	}
+
+	// Sampler decides whether a given request should be traced. A nil
+	// Sampler traces every request that has a Tracer configured.
+	Sampler func(req *Request) bool
+
+	// Redactor scrubs sensitive header and query parameter values before
+	// they reach a Tracer.
+	Redactor interface {
+		RedactHeader(header http.Header) http.Header
+		RedactQuery(query neturl.Values) neturl.Values
+	}
+)
+
+// SetTracer registers a Tracer that receives structured lifecycle events
+// for every sampled request. See SetTraceSampler to rate-limit capture.
+func (c *Client) SetTracer(t Tracer) *Client {
+	c.tracer = t
+	return c
+}
+
+// SetTraceSampler restricts tracing to requests for which sample returns
+// true, so production deployments can capture a fraction of traffic.
+func (c *Client) SetTraceSampler(sample Sampler) *Client {
+	c.traceSampler = sample
+	return c
+}
+
+// SetRedactor installs a Redactor that scrubs sensitive values from
+// captured requests before they're reported to a Tracer. DefaultRedactor
+// is used when none is set.
+func (c *Client) SetRedactor(r Redactor) *Client {
+	c.redactor = r
+	return c
+}
+
+func (c *Client) shouldTrace(req *Request) bool {
+	if c.tracer == nil {
+		return false
+	}
+	if c.traceSampler == nil {
+		return true
+	}
+	return c.traceSampler(req)
+}
+
+func (c *Client) traceEvent(name string, req *Request, err error) {
+	redactor := c.redactor
+	if redactor == nil {
+		redactor = DefaultRedactor
+	}
+
+	u := req.RawRequest.URL
+	c.tracer.Trace(TraceEvent{
+		Name:      name,
+		Timestamp: time.Now(),
+		Method:    req.RawRequest.Method,
+		URL:       u.String(),
+		Host:      u.Host,
+		Headers:   redactor.RedactHeader(req.RawRequest.Header),
+		Query:     redactor.RedactQuery(u.Query()),
+		Err:       err,
+	})
+}
+
+// attachClientTrace wires a net/http/httptrace.ClientTrace into req's
+// context so the rest of its lifecycle emits TraceEvents.
+func (c *Client) attachClientTrace(req *Request) {
+	ct := &httptrace.ClientTrace{
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			c.traceEvent(TraceDNSDone, req, nil)
+		},
+		ConnectDone: func(_ string, _ string, err error) {
+			c.traceEvent(TraceConnectDone, req, err)
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			c.traceEvent(TraceTLSHandshakeDone, req, err)
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			c.traceEvent(TraceWroteRequest, req, info.Err)
+		},
+		GotFirstResponseByte: func() {
+			c.traceEvent(TraceGotFirstResponseByte, req, nil)
+		},
+	}
+
+	req.RawRequest = req.RawRequest.WithContext(
+		httptrace.WithClientTrace(req.RawRequest.Context(), ct))
+}
+
+// redactedValue replaces any scrubbed header or query parameter value.
+const redactedValue = "[REDACTED]"
+
+type redactor struct {
+	headers map[string]bool
+	query   map[string]bool
+}
+
+// DefaultRedactor scrubs the Authorization and Cookie headers.
+var DefaultRedactor = NewRedactor(nil, nil)
+
+// NewRedactor returns a Redactor that scrubs the Authorization and Cookie
+// headers along with any extra header or query parameter names given.
+func NewRedactor(extraHeaders, extraQueryParams []string) Redactor {
+	headers := map[string]bool{
+		http.CanonicalHeaderKey("Authorization"): true,
+		http.CanonicalHeaderKey("Cookie"):        true,
+	}
+	for _, name := range extraHeaders {
+		headers[http.CanonicalHeaderKey(name)] = true
+	}
+
+	query := make(map[string]bool, len(extraQueryParams))
+	for _, name := range extraQueryParams {
+		query[name] = true
+	}
+
+	return &redactor{headers: headers, query: query}
+}
+
+func (r *redactor) RedactHeader(header http.Header) http.Header {
+	out := header.Clone()
+	for name := range out {
+		if r.headers[http.CanonicalHeaderKey(name)] {
+			out.Set(name, redactedValue)
+		}
+	}
+	return out
+}
+
+func (r *redactor) RedactQuery(query neturl.Values) neturl.Values {
+	out := make(neturl.Values, len(query))
+	for name, values := range query {
+		if r.query[name] {
+			out[name] = []string{redactedValue}
+			continue
+		}
+		out[name] = values
+	}
+	return out
+}
+
+// JSONTracer returns a Tracer that writes one JSON-encoded TraceEvent per
+// line to w.
+func JSONTracer(w io.Writer) Tracer {
+	return &jsonTracer{w: w}
+}
+
+type jsonTracer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (t *jsonTracer) Trace(event TraceEvent) {
+	b, err := json.Marshal(traceEventJSON(event))
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.w.Write(b)
+}
+
+// traceEventJSON swaps Err for its string form, since error doesn't
+// implement json.Marshaler.
+type traceEventJSON TraceEvent
+
+func (e traceEventJSON) MarshalJSON() ([]byte, error) {
+	var errMsg string
+	if e.Err != nil {
+		errMsg = e.Err.Error()
+	}
+
+	return json.Marshal(struct {
+		Name      string        `json:"name"`
+		Timestamp time.Time     `json:"timestamp"`
+		Method    string        `json:"method"`
+		URL       string        `json:"url"`
+		Host      string        `json:"host"`
+		Headers   http.Header   `json:"headers,omitempty"`
+		Query     neturl.Values `json:"query,omitempty"`
+		Err       string        `json:"err,omitempty"`
+	}{e.Name, e.Timestamp, e.Method, e.URL, e.Host, e.Headers, e.Query, errMsg})
+}
+
+// MemoryTracer accumulates trace events in memory, for use in tests.
+type MemoryTracer struct {
+	mu     sync.Mutex
+	Events []TraceEvent
+}
+
+// NewMemoryTracer returns a new MemoryTracer.
+func NewMemoryTracer() *MemoryTracer {
+	return &MemoryTracer{}
+}
+
+func (t *MemoryTracer) Trace(event TraceEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Events = append(t.Events, event)
+}